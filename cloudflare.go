@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Defaults applied when the corresponding CloudFlareQuery retry knob is
+// left at its zero value.
+const (
+	defaultMaxRetries     = 5
+	defaultRetryBaseDelay = 1 * time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// CloudFlareQuery carries the credentials and root URL used to build
+// requests against the CloudFlare v4 API.
+//
+// Authentication precedence: if AuthToken is set, requests are
+// authenticated with `Authorization: Bearer <AuthToken>` (the scoped
+// API token scheme CloudFlare now recommends). Otherwise the legacy
+// `X-Auth-Email` / `X-Auth-Key` headers are sent using AuthEmail and
+// AuthKey.
+// If Provider is set, it is consulted on every request instead of
+// AuthEmail/AuthKey/AuthToken, so credentials can be rotated without
+// rebuilding the query.
+//
+// MaxRetries, RetryBaseDelay and RetryMaxDelay tune how makeRequest
+// retries transient failures (429 and 5xx responses, and network
+// errors). Each is left at CloudFlare-sane defaults when zero; see
+// defaultMaxRetries, defaultRetryBaseDelay and defaultRetryMaxDelay.
+type CloudFlareQuery struct {
+	RootURL   string
+	AuthEmail string
+	AuthKey   string
+	AuthToken string
+	Provider  CredentialProvider
+
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// resolveAuth returns the credentials to authenticate a request with,
+// preferring Provider when set over the static AuthEmail/AuthKey/AuthToken
+// fields.
+func (q *CloudFlareQuery) resolveAuth() (email, key, token string, err error) {
+	if q.Provider != nil {
+		return q.Provider.Credentials()
+	}
+	return q.AuthEmail, q.AuthKey, q.AuthToken, nil
+}
+
+// NewRequest builds an *http.Request against the CloudFlare API rooted
+// at q.RootURL, authenticating it according to the precedence described
+// on CloudFlareQuery.
+func (q *CloudFlareQuery) NewRequest(method, path string) (*http.Request, error) {
+	return q.NewRequestWithBody(method, path, nil)
+}
+
+// NewRequestWithBody is like NewRequest but attaches body as the JSON
+// request payload.
+func (q *CloudFlareQuery) NewRequestWithBody(method, path string, body interface{}) (*http.Request, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, q.RootURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	email, key, token, err := q.resolveAuth()
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare: unable to resolve credentials: %s", err.Error())
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("X-Auth-Email", email)
+		req.Header.Set("X-Auth-Key", key)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
+
+// CloudFlareZoneItem is a single zone as returned by GET /zones.
+type CloudFlareZoneItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// CloudFlareConfigItem is a single zone setting as returned by
+// GET /zones/{id}/settings.
+type CloudFlareConfigItem struct {
+	ID         string      `json:"id"`
+	Value      interface{} `json:"value"`
+	ModifiedOn string      `json:"modified_on"`
+	Editable   bool        `json:"editable"`
+}
+
+// CloudFlareRequestItem is the request body sent when changing a single
+// zone setting.
+type CloudFlareRequestItem struct {
+	Value interface{} `json:"value"`
+}
+
+// cloudFlareResponse is the envelope CloudFlare wraps every API response
+// in, regardless of the shape of Result.
+type cloudFlareResponse struct {
+	Errors   []string        `json:"errors"`
+	Messages []string        `json:"messages"`
+	Result   json.RawMessage `json:"result"`
+	Success  bool            `json:"success"`
+}
+
+// CloudFlare is a client for the subset of the CloudFlare v4 API this
+// tool needs, authenticated using the credentials in Query.
+type CloudFlare struct {
+	Query  *CloudFlareQuery
+	client *http.Client
+}
+
+// NewCloudFlare builds a CloudFlare client from query.
+func NewCloudFlare(query *CloudFlareQuery) *CloudFlare {
+	return &CloudFlare{
+		Query:  query,
+		client: &http.Client{},
+	}
+}
+
+// makeRequest executes req and unwraps the CloudFlare response envelope,
+// returning an error if the request failed, the HTTP status wasn't 200,
+// or the API reported success: false / a non-empty errors list.
+//
+// Rate limiting (429, honoring Retry-After) and 5xx/network errors are
+// retried with exponential backoff and jitter, up to req.Query.MaxRetries
+// times, bounded by req.Query.RetryBaseDelay/RetryMaxDelay. Any other
+// 4xx status, or an unsuccessful-but-200 envelope, is not retried.
+func (c *CloudFlare) makeRequest(req *http.Request) (json.RawMessage, error) {
+	maxRetries := c.Query.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	baseDelay := c.Query.RetryBaseDelay
+	if baseDelay <= 0 {
+		baseDelay = defaultRetryBaseDelay
+	}
+	maxDelay := c.Query.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		result, retryable, retryAfter, err := c.attempt(attemptReq)
+		if err == nil {
+			return result, nil
+		}
+		if !retryable || attempt >= maxRetries {
+			return nil, err
+		}
+
+		delay := retryAfter
+		if delay <= 0 {
+			delay = backoffDelay(attempt, baseDelay, maxDelay)
+		}
+		if sleepErr := sleepContext(req.Context(), delay); sleepErr != nil {
+			return nil, sleepErr
+		}
+
+		attemptReq, err = rewindRequest(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt performs a single HTTP round trip and classifies the outcome:
+// whether it's retryable, and how long to wait before retrying if the
+// server told us explicitly (via Retry-After).
+func (c *CloudFlare) attempt(req *http.Request) (result json.RawMessage, retryable bool, retryAfter time.Duration, err error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, true, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, 0, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, true, parseRetryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("cloudflare: rate limited (429): %s", string(body))
+	case resp.StatusCode >= 500:
+		return nil, true, 0, fmt.Errorf("cloudflare: server error %d: %s", resp.StatusCode, string(body))
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, 0, fmt.Errorf("cloudflare: unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var envelope cloudFlareResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, false, 0, fmt.Errorf("cloudflare: unable to parse response: %s", err.Error())
+	}
+
+	if len(envelope.Errors) > 0 {
+		return nil, false, 0, fmt.Errorf("cloudflare: API returned errors: %v", envelope.Errors)
+	}
+
+	if !envelope.Success {
+		return nil, false, 0, fmt.Errorf("cloudflare: request was not successful")
+	}
+
+	return envelope.Result, false, 0, nil
+}
+
+// rewindRequest clones req with a fresh copy of its body so it can be
+// safely resent after a failed attempt.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// backoffDelay computes the exponential backoff (base * 2^attempt,
+// capped at maxDelay) with +/-20% jitter applied.
+func backoffDelay(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base * time.Duration(uint64(1)<<uint(attempt))
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+
+	jitter := time.Duration(float64(delay) * 0.2)
+	if jitter <= 0 {
+		return delay
+	}
+	return delay - jitter + time.Duration(rand.Int63n(int64(2*jitter)+1))
+}
+
+// parseRetryAfter parses a Retry-After header value, which CloudFlare
+// sends either as a number of seconds or an HTTP-date. It returns 0 if
+// the header is absent or unparseable.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// sleepContext waits for d, returning early with ctx.Err() if ctx is
+// canceled or its deadline expires first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Zones returns every zone visible to the authenticated account.
+func (c *CloudFlare) Zones() ([]CloudFlareZoneItem, error) {
+	req, err := c.Query.NewRequest("GET", "/zones")
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.makeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []CloudFlareZoneItem
+	if err := json.Unmarshal(result, &zones); err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// Settings returns every setting for the given zone.
+func (c *CloudFlare) Settings(zoneID string) ([]CloudFlareConfigItem, error) {
+	req, err := c.Query.NewRequest("GET", fmt.Sprintf("/zones/%s/settings", zoneID))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.makeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings []CloudFlareConfigItem
+	if err := json.Unmarshal(result, &settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// Set changes a single zone setting to value.
+func (c *CloudFlare) Set(zoneID, settingID string, value interface{}) error {
+	path := fmt.Sprintf("/zones/%s/settings/%s", zoneID, settingID)
+	req, err := c.Query.NewRequestWithBody("PATCH", path, &CloudFlareRequestItem{Value: value})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.makeRequest(req)
+	return err
+}
@@ -7,8 +7,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func testCloudFlareServer(status int, body string) *httptest.Server {
@@ -93,7 +96,12 @@ func TestMakingARequestWithout200Code(t *testing.T) {
 	testServer := testCloudFlareServer(500, ``)
 	defer testServer.Close()
 
-	query := &CloudFlareQuery{RootURL: testServer.URL}
+	query := &CloudFlareQuery{
+		RootURL:        testServer.URL,
+		MaxRetries:     1,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  time.Millisecond,
+	}
 	cloudFlare := NewCloudFlare(query)
 
 	req, _ := query.NewRequest("GET", "/foo")
@@ -214,4 +222,200 @@ func TestChangeSetting(t *testing.T) {
 	if !receivedRequest {
 		t.Fatal("Expected test server to receive request")
 	}
-}
\ No newline at end of file
+}
+
+func TestNewRequestUsesEmailAndKeyByDefault(t *testing.T) {
+	query := &CloudFlareQuery{
+		RootURL:   "https://example.com",
+		AuthEmail: "user@example.com",
+		AuthKey:   "abc123",
+	}
+
+	req, err := query.NewRequest("GET", "/zones")
+	if err != nil {
+		t.Fatal("Unable to build request", err)
+	}
+
+	if email := req.Header.Get("X-Auth-Email"); email != "user@example.com" {
+		t.Fatal("Incorrect X-Auth-Email header", email)
+	}
+	if key := req.Header.Get("X-Auth-Key"); key != "abc123" {
+		t.Fatal("Incorrect X-Auth-Key header", key)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		t.Fatal("Expected no Authorization header when using email/key", auth)
+	}
+}
+
+func TestNewRequestPrefersTokenOverEmailAndKey(t *testing.T) {
+	query := &CloudFlareQuery{
+		RootURL:   "https://example.com",
+		AuthEmail: "user@example.com",
+		AuthKey:   "abc123",
+		AuthToken: "my-scoped-token",
+	}
+
+	req, err := query.NewRequest("GET", "/zones")
+	if err != nil {
+		t.Fatal("Unable to build request", err)
+	}
+
+	if auth := req.Header.Get("Authorization"); auth != "Bearer my-scoped-token" {
+		t.Fatal("Incorrect Authorization header", auth)
+	}
+	if email := req.Header.Get("X-Auth-Email"); email != "" {
+		t.Fatal("Expected no X-Auth-Email header when using a token", email)
+	}
+	if key := req.Header.Get("X-Auth-Key"); key != "" {
+		t.Fatal("Expected no X-Auth-Key header when using a token", key)
+	}
+}
+
+func TestChangeSettingWithAPIToken(t *testing.T) {
+	const zoneID = "123"
+	const settingID = "always_online"
+	const settingVal = "off"
+
+	receivedRequest := false
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = true
+
+		if auth := r.Header.Get("Authorization"); auth != "Bearer my-scoped-token" {
+			t.Fatal("Incorrect Authorization header", auth)
+		}
+		if email := r.Header.Get("X-Auth-Email"); email != "" {
+			t.Fatal("Expected no X-Auth-Email header when using a token", email)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{
+			"errors": [],
+			"messages": [],
+			"result": {
+				"id": "always_online",
+				"value": "off",
+				"modified_on": "2014-07-09T11:50:56.595672Z",
+				"editable": true
+			},
+			"success": true
+		}`)
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{
+		RootURL:   testServer.URL,
+		AuthToken: "my-scoped-token",
+	}
+	cloudFlare := NewCloudFlare(query)
+
+	err := cloudFlare.Set(zoneID, settingID, settingVal)
+	if err != nil {
+		t.Fatal("Unable to set setting")
+	}
+
+	if !receivedRequest {
+		t.Fatal("Expected test server to receive request")
+	}
+}
+
+func TestMakeRequestRetriesRateLimitAndServerErrors(t *testing.T) {
+	var attempts int32
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, `{
+				"errors": [],
+				"messages": [],
+				"result": [{"id": "foo", "name": "bar"}],
+				"success": true
+			}`)
+		}
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{
+		RootURL:        testServer.URL,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  10 * time.Millisecond,
+	}
+	cloudFlare := NewCloudFlare(query)
+
+	zones, err := cloudFlare.Zones()
+	if err != nil {
+		t.Fatal("Expected retries to eventually succeed", err)
+	}
+	if len(zones) != 1 || zones[0].ID != "foo" {
+		t.Fatal("Got unexpected zones back", zones)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", got)
+	}
+}
+
+func TestMakeRequestGivesUpAfterMaxRetries(t *testing.T) {
+	testServer := testCloudFlareServer(http.StatusServiceUnavailable, ``)
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{
+		RootURL:        testServer.URL,
+		MaxRetries:     2,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  time.Millisecond,
+	}
+	cloudFlare := NewCloudFlare(query)
+
+	req, _ := query.NewRequest("GET", "/zones")
+	_, err := cloudFlare.makeRequest(req)
+	if err == nil {
+		t.Fatal("Expected an error once retries are exhausted")
+	}
+}
+
+func TestMakingARequestWith4xxIsNotRetried(t *testing.T) {
+	var attempts int32
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{
+		RootURL:        testServer.URL,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  time.Millisecond,
+	}
+	cloudFlare := NewCloudFlare(query)
+
+	req, _ := query.NewRequest("GET", "/zones")
+	_, err := cloudFlare.makeRequest(req)
+	if err == nil {
+		t.Fatal("Expected an error for a non-retryable 4xx response")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Fatalf("Expected exactly 1 attempt for a non-retryable error, got %d", got)
+	}
+}
+
+func TestParseRetryAfterSeconds(t *testing.T) {
+	delay := parseRetryAfter(strconv.Itoa(5))
+	if delay != 5*time.Second {
+		t.Fatalf("Expected a 5s delay, got %s", delay)
+	}
+}
+
+func TestParseRetryAfterHTTPDate(t *testing.T) {
+	future := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+	delay := parseRetryAfter(future)
+	if delay <= 0 || delay > 10*time.Second {
+		t.Fatalf("Expected a positive delay under 10s, got %s", delay)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// ZoneConfig is the declarative description of a single zone: its
+// settings, DNS records, and Rulesets entrypoints, as loaded from a
+// config file. Rulesets is keyed by phase (see the Phase* constants in
+// rulesets.go).
+type ZoneConfig struct {
+	ID         string                       `json:"id"`
+	Name       string                       `json:"name,omitempty"`
+	Settings   map[string]interface{}       `json:"settings,omitempty"`
+	DNSRecords []CloudFlareDNSRecord        `json:"dns_records,omitempty"`
+	Rulesets   map[string]CloudFlareRuleset `json:"rulesets,omitempty"`
+}
+
+// Config is the top-level declarative description of every zone this
+// tool manages, as loaded from a config file.
+type Config struct {
+	Zones []ZoneConfig `json:"zones"`
+}
+
+// LoadConfig reads and parses the config file at path. Only JSON is
+// supported; YAML is deliberately out of scope for now since it would
+// pull in a third-party dependency this module otherwise has no need
+// for.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// zoneByID returns the ZoneConfig with the given ID, or nil if none
+// matches.
+func (c *Config) zoneByID(zoneID string) *ZoneConfig {
+	for i := range c.Zones {
+		if c.Zones[i].ID == zoneID {
+			return &c.Zones[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// CredentialProvider resolves the credentials used to authenticate
+// CloudFlare API requests. Implementations are consulted on every
+// request (via CloudFlareQuery.Provider) rather than once at startup,
+// so a short-lived token can be rotated without restarting.
+type CredentialProvider interface {
+	Credentials() (email, key, token string, err error)
+}
+
+// EnvProvider resolves credentials from the environment, matching the
+// variable names lego and cloudflare-go use: CLOUDFLARE_EMAIL,
+// CLOUDFLARE_API_KEY and CLOUDFLARE_API_TOKEN.
+type EnvProvider struct{}
+
+// Credentials implements CredentialProvider.
+func (EnvProvider) Credentials() (email, key, token string, err error) {
+	return os.Getenv("CLOUDFLARE_EMAIL"), os.Getenv("CLOUDFLARE_API_KEY"), os.Getenv("CLOUDFLARE_API_TOKEN"), nil
+}
+
+// FileProvider resolves credentials from a JSON file with "email",
+// "key" and "token" fields. The file must not be readable by group or
+// other, since it holds a plaintext credential. Only JSON is supported;
+// YAML is deliberately out of scope for now since it would pull in a
+// third-party dependency this module otherwise has no need for.
+type FileProvider struct {
+	Path string
+}
+
+type fileProviderCredentials struct {
+	Email string `json:"email"`
+	Key   string `json:"key"`
+	Token string `json:"token"`
+}
+
+// Credentials implements CredentialProvider.
+func (p FileProvider) Credentials() (email, key, token string, err error) {
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return "", "", "", fmt.Errorf("credentials: %s is readable by group or other (mode %04o); chmod 0600 it", p.Path, perm)
+	}
+
+	data, err := ioutil.ReadFile(p.Path)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var creds fileProviderCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", "", "", fmt.Errorf("credentials: unable to parse %s: %s", p.Path, err.Error())
+	}
+
+	return creds.Email, creds.Key, creds.Token, nil
+}
+
+// ChainProvider tries each provider in order, returning the first one
+// that yields usable credentials (a token, or both an email and a key).
+// A provider that errors is skipped in favor of the next; ChainProvider
+// only errors itself if every provider does.
+type ChainProvider struct {
+	Providers []CredentialProvider
+}
+
+// Credentials implements CredentialProvider.
+func (c ChainProvider) Credentials() (email, key, token string, err error) {
+	var lastErr error
+
+	for _, provider := range c.Providers {
+		email, key, token, err := provider.Credentials()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if token != "" || (email != "" && key != "") {
+			return email, key, token, nil
+		}
+	}
+
+	if lastErr != nil {
+		return "", "", "", lastErr
+	}
+	return "", "", "", fmt.Errorf("credentials: no provider in the chain produced usable credentials")
+}
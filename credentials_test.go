@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+type staticProvider struct {
+	email, key, token string
+	err               error
+}
+
+func (p staticProvider) Credentials() (string, string, string, error) {
+	return p.email, p.key, p.token, p.err
+}
+
+func TestChainProviderFallsBackToNextProvider(t *testing.T) {
+	chain := ChainProvider{
+		Providers: []CredentialProvider{
+			staticProvider{err: fmt.Errorf("boom")},
+			staticProvider{email: "", key: "", token: ""},
+			staticProvider{token: "from-third-provider"},
+		},
+	}
+
+	email, key, token, err := chain.Credentials()
+	if err != nil {
+		t.Fatal("Expected chain to fall through to a usable provider", err)
+	}
+	if token != "from-third-provider" || email != "" || key != "" {
+		t.Fatal("Expected credentials from the third provider", email, key, token)
+	}
+}
+
+func TestChainProviderErrorsWhenNoProviderIsUsable(t *testing.T) {
+	chain := ChainProvider{
+		Providers: []CredentialProvider{
+			staticProvider{err: fmt.Errorf("boom")},
+		},
+	}
+
+	if _, _, _, err := chain.Credentials(); err == nil {
+		t.Fatal("Expected an error when every provider fails")
+	}
+}
+
+func TestFileProviderRejectsLaxPermissions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.json")
+	if err := ioutil.WriteFile(path, []byte(`{"token": "abc"}`), 0644); err != nil {
+		t.Fatal("Unable to write test credentials file", err)
+	}
+
+	provider := FileProvider{Path: path}
+	if _, _, _, err := provider.Credentials(); err == nil {
+		t.Fatal("Expected world-readable credentials file to be rejected")
+	}
+}
+
+func TestFileProviderReadsStrictlyPermissionedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.json")
+	if err := ioutil.WriteFile(path, []byte(`{"email": "user@example.com", "key": "abc123"}`), 0600); err != nil {
+		t.Fatal("Unable to write test credentials file", err)
+	}
+
+	provider := FileProvider{Path: path}
+	email, key, token, err := provider.Credentials()
+	if err != nil {
+		t.Fatal("Expected a strictly permissioned file to be readable", err)
+	}
+	if email != "user@example.com" || key != "abc123" || token != "" {
+		t.Fatal("Credentials didn't match file contents", email, key, token)
+	}
+}
+
+func TestRotatedTokenIsPickedUpOnNextRequest(t *testing.T) {
+	currentToken := "first-token"
+	provider := staticProviderFunc(func() (string, string, string, error) {
+		return "", "", currentToken, nil
+	})
+
+	var lastAuth string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastAuth = r.Header.Get("Authorization")
+		fmt.Fprintf(w, `{"errors": [], "messages": [], "success": true, "result": []}`)
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL, Provider: provider}
+	cloudFlare := NewCloudFlare(query)
+
+	if _, err := cloudFlare.Zones(); err != nil {
+		t.Fatal("Unable to fetch zones", err)
+	}
+	if lastAuth != "Bearer first-token" {
+		t.Fatal("Incorrect Authorization header on first request", lastAuth)
+	}
+
+	currentToken = "rotated-token"
+
+	if _, err := cloudFlare.Zones(); err != nil {
+		t.Fatal("Unable to fetch zones after rotation", err)
+	}
+	if lastAuth != "Bearer rotated-token" {
+		t.Fatal("Expected the rotated token to be used without rebuilding the client", lastAuth)
+	}
+}
+
+// staticProviderFunc adapts a function to CredentialProvider, letting
+// tests simulate a provider whose underlying secret changes over time.
+type staticProviderFunc func() (string, string, string, error)
+
+func (f staticProviderFunc) Credentials() (string, string, string, error) {
+	return f()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// CloudFlareDNSRecord is a single DNS record as returned by, or sent to,
+// /zones/{id}/dns_records.
+type CloudFlareDNSRecord struct {
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	TTL      int    `json:"ttl,omitempty"`
+	Proxied  bool   `json:"proxied,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// resolveApexName rewrites the apex shorthand "@" to zoneName, the
+// convention CloudFlare and most DNS tooling use for "the zone itself".
+func resolveApexName(name, zoneName string) string {
+	if name == "@" {
+		return zoneName
+	}
+	return name
+}
+
+// DNSRecords returns every DNS record in the given zone.
+func (c *CloudFlare) DNSRecords(zoneID string) ([]CloudFlareDNSRecord, error) {
+	req, err := c.Query.NewRequest("GET", fmt.Sprintf("/zones/%s/dns_records", zoneID))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.makeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []CloudFlareDNSRecord
+	if err := json.Unmarshal(result, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// CreateDNSRecord creates record in the given zone and returns the
+// record CloudFlare stored, including its assigned ID.
+func (c *CloudFlare) CreateDNSRecord(zoneID string, record CloudFlareDNSRecord) (CloudFlareDNSRecord, error) {
+	path := fmt.Sprintf("/zones/%s/dns_records", zoneID)
+	req, err := c.Query.NewRequestWithBody("POST", path, &record)
+	if err != nil {
+		return CloudFlareDNSRecord{}, err
+	}
+
+	result, err := c.makeRequest(req)
+	if err != nil {
+		return CloudFlareDNSRecord{}, err
+	}
+
+	var created CloudFlareDNSRecord
+	if err := json.Unmarshal(result, &created); err != nil {
+		return CloudFlareDNSRecord{}, err
+	}
+
+	return created, nil
+}
+
+// UpdateDNSRecord overwrites the record identified by recordID in the
+// given zone.
+func (c *CloudFlare) UpdateDNSRecord(zoneID, recordID string, record CloudFlareDNSRecord) (CloudFlareDNSRecord, error) {
+	path := fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, recordID)
+	req, err := c.Query.NewRequestWithBody("PUT", path, &record)
+	if err != nil {
+		return CloudFlareDNSRecord{}, err
+	}
+
+	result, err := c.makeRequest(req)
+	if err != nil {
+		return CloudFlareDNSRecord{}, err
+	}
+
+	var updated CloudFlareDNSRecord
+	if err := json.Unmarshal(result, &updated); err != nil {
+		return CloudFlareDNSRecord{}, err
+	}
+
+	return updated, nil
+}
+
+// DeleteDNSRecord removes the record identified by recordID from the
+// given zone.
+func (c *CloudFlare) DeleteDNSRecord(zoneID, recordID string) error {
+	path := fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, recordID)
+	req, err := c.Query.NewRequest("DELETE", path)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.makeRequest(req)
+	return err
+}
+
+// dnsRecordDiff is the set of DNS record changes needed to move a zone
+// from its current records to a desired set.
+type dnsRecordDiff struct {
+	Creates []CloudFlareDNSRecord
+	Updates []CloudFlareDNSRecord
+	Deletes []CloudFlareDNSRecord
+}
+
+// dnsRecordKey identifies a record for matching current state against
+// desired state, independent of its CloudFlare-assigned ID. It includes
+// Content (and Priority, for MX/SRV) alongside Type and Name so that
+// routine same-name setups - round-robin A records, multiple MX or TXT
+// records at one name - aren't aliased onto a single map entry.
+func dnsRecordKey(record CloudFlareDNSRecord) string {
+	return fmt.Sprintf("%s|%s|%s|%d", record.Type, record.Name, record.Content, record.Priority)
+}
+
+// diffDNSRecords compares current against desired (resolving any "@"
+// apex names against zoneName first) and reports the creates, updates
+// and deletes needed to reconcile them. Records are matched one-to-one:
+// if several current records share a key (duplicates), each is paired
+// with at most one desired record carrying the same key.
+func diffDNSRecords(current, desired []CloudFlareDNSRecord, zoneName string) dnsRecordDiff {
+	currentByKey := make(map[string][]CloudFlareDNSRecord, len(current))
+	for _, record := range current {
+		key := dnsRecordKey(record)
+		currentByKey[key] = append(currentByKey[key], record)
+	}
+
+	var diff dnsRecordDiff
+
+	for _, record := range desired {
+		record.Name = resolveApexName(record.Name, zoneName)
+		key := dnsRecordKey(record)
+
+		if matches := currentByKey[key]; len(matches) > 0 {
+			existing := matches[0]
+			currentByKey[key] = matches[1:]
+
+			record.ID = existing.ID
+			if !reflect.DeepEqual(record, existing) {
+				diff.Updates = append(diff.Updates, record)
+			}
+		} else {
+			diff.Creates = append(diff.Creates, record)
+		}
+	}
+
+	for _, remaining := range currentByKey {
+		diff.Deletes = append(diff.Deletes, remaining...)
+	}
+
+	return diff
+}
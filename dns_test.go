@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGettingDNSRecords(t *testing.T) {
+	testServer := testCloudFlareServer(200, `{
+		"errors": [],
+		"messages": [],
+		"result": [{"id": "rec1", "type": "A", "name": "www.example.com", "content": "1.2.3.4", "ttl": 300, "proxied": true}],
+		"success": true
+	}`)
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL}
+	cloudFlare := NewCloudFlare(query)
+
+	records, err := cloudFlare.DNSRecords("123")
+	if err != nil {
+		t.Fatal("Expected to get DNS records with no errors", err)
+	}
+
+	expected := []CloudFlareDNSRecord{
+		{ID: "rec1", Type: "A", Name: "www.example.com", Content: "1.2.3.4", TTL: 300, Proxied: true},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatal("DNS records response doesn't match", records)
+	}
+}
+
+func TestCreateDNSRecord(t *testing.T) {
+	const zoneID = "123"
+	receivedRequest := false
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = true
+
+		if method := r.Method; method != "POST" {
+			t.Fatal("Incorrect request method", method)
+		}
+
+		expectedURL := fmt.Sprintf("/zones/%s/dns_records", zoneID)
+		if r.URL.Path != expectedURL {
+			t.Fatal("Request URL was incorrect", r.URL.Path)
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal("Unable to read request body", err)
+		}
+
+		var record CloudFlareDNSRecord
+		if err := json.Unmarshal(body, &record); err != nil {
+			t.Fatal("Unable to parse request body", err)
+		}
+
+		expected := CloudFlareDNSRecord{Type: "A", Name: "www.example.com", Content: "1.2.3.4"}
+		if !reflect.DeepEqual(record, expected) {
+			t.Fatal("Request was incorrect", record)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{
+			"errors": [],
+			"messages": [],
+			"result": {"id": "rec1", "type": "A", "name": "www.example.com", "content": "1.2.3.4"},
+			"success": true
+		}`)
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL}
+	cloudFlare := NewCloudFlare(query)
+
+	created, err := cloudFlare.CreateDNSRecord(zoneID, CloudFlareDNSRecord{Type: "A", Name: "www.example.com", Content: "1.2.3.4"})
+	if err != nil {
+		t.Fatal("Unable to create DNS record", err)
+	}
+	if created.ID != "rec1" {
+		t.Fatal("Expected created record to carry its assigned ID", created)
+	}
+	if !receivedRequest {
+		t.Fatal("Expected test server to receive request")
+	}
+}
+
+func TestDeleteDNSRecord(t *testing.T) {
+	const zoneID = "123"
+	const recordID = "rec1"
+	receivedRequest := false
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = true
+
+		if method := r.Method; method != "DELETE" {
+			t.Fatal("Incorrect request method", method)
+		}
+
+		expectedURL := fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, recordID)
+		if r.URL.Path != expectedURL {
+			t.Fatal("Request URL was incorrect", r.URL.Path)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{
+			"errors": [],
+			"messages": [],
+			"result": {},
+			"success": true
+		}`)
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL}
+	cloudFlare := NewCloudFlare(query)
+
+	if err := cloudFlare.DeleteDNSRecord(zoneID, recordID); err != nil {
+		t.Fatal("Unable to delete DNS record", err)
+	}
+	if !receivedRequest {
+		t.Fatal("Expected test server to receive request")
+	}
+}
+
+func TestDiffDNSRecordsResolvesApexAndClassifiesChanges(t *testing.T) {
+	current := []CloudFlareDNSRecord{
+		{ID: "rec1", Type: "A", Name: "example.com", Content: "1.1.1.1", Proxied: false},
+		{ID: "rec2", Type: "A", Name: "stale.example.com", Content: "9.9.9.9"},
+	}
+	desired := []CloudFlareDNSRecord{
+		{Type: "A", Name: "@", Content: "1.1.1.1", Proxied: true},
+		{Type: "A", Name: "new.example.com", Content: "3.3.3.3"},
+	}
+
+	diff := diffDNSRecords(current, desired, "example.com")
+
+	if len(diff.Updates) != 1 || diff.Updates[0].ID != "rec1" || !diff.Updates[0].Proxied {
+		t.Fatal("Expected the apex record's Proxied flag to flip as an update", diff.Updates)
+	}
+	if len(diff.Creates) != 1 || diff.Creates[0].Name != "new.example.com" {
+		t.Fatal("Expected the new record to be a create", diff.Creates)
+	}
+	if len(diff.Deletes) != 1 || diff.Deletes[0].ID != "rec2" {
+		t.Fatal("Expected the stale record to be a delete", diff.Deletes)
+	}
+}
+
+func TestDiffDNSRecordsTreatsContentChangeAsCreateAndDelete(t *testing.T) {
+	current := []CloudFlareDNSRecord{
+		{ID: "rec1", Type: "A", Name: "www.example.com", Content: "1.1.1.1"},
+	}
+	desired := []CloudFlareDNSRecord{
+		{Type: "A", Name: "www.example.com", Content: "2.2.2.2"},
+	}
+
+	diff := diffDNSRecords(current, desired, "example.com")
+
+	if len(diff.Creates) != 1 || diff.Creates[0].Content != "2.2.2.2" {
+		t.Fatal("Expected the new content to be a create", diff.Creates)
+	}
+	if len(diff.Deletes) != 1 || diff.Deletes[0].ID != "rec1" {
+		t.Fatal("Expected the old record to be a delete, not silently aliased", diff.Deletes)
+	}
+	if len(diff.Updates) != 0 {
+		t.Fatal("Did not expect an update when content differs", diff.Updates)
+	}
+}
+
+func TestDiffDNSRecordsDoesNotAliasDuplicateTypeAndName(t *testing.T) {
+	current := []CloudFlareDNSRecord{
+		{ID: "mx1", Type: "MX", Name: "example.com", Content: "mail1.example.com", Priority: 10},
+		{ID: "mx2", Type: "MX", Name: "example.com", Content: "mail2.example.com", Priority: 20},
+	}
+	desired := []CloudFlareDNSRecord{
+		{Type: "MX", Name: "@", Content: "mail1.example.com", Priority: 10},
+		{Type: "MX", Name: "@", Content: "mail2.example.com", Priority: 20},
+	}
+
+	diff := diffDNSRecords(current, desired, "example.com")
+
+	if len(diff.Creates) != 0 || len(diff.Updates) != 0 || len(diff.Deletes) != 0 {
+		t.Fatal("Expected both MX records to match their counterparts with no changes", diff)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// queryFromEnv builds a CloudFlareQuery backed by a ChainProvider: the
+// environment (CLOUDFLARE_EMAIL/CLOUDFLARE_API_KEY/CLOUDFLARE_API_TOKEN)
+// is tried first, falling back to the credentials file at
+// CLOUDFLARE_CREDENTIALS_FILE (default ~/.cloudflare/credentials.json).
+// Within either source, an API token wins over email+key and is sent as
+// a Bearer token. Credentials are re-resolved on every request, so a
+// rotated token is picked up without restarting.
+func queryFromEnv() *CloudFlareQuery {
+	return &CloudFlareQuery{
+		RootURL: "https://api.cloudflare.com/client/v4",
+		Provider: ChainProvider{
+			Providers: []CredentialProvider{
+				EnvProvider{},
+				FileProvider{Path: credentialsFilePath()},
+			},
+		},
+	}
+}
+
+func credentialsFilePath() string {
+	if path := os.Getenv("CLOUDFLARE_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cloudflare", "credentials.json")
+}
+
+func main() {
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: cloudflare-configure <zones|settings|set> ...")
+		os.Exit(1)
+	}
+
+	cloudFlare := NewCloudFlare(queryFromEnv())
+
+	var err error
+	switch args[0] {
+	case "zones":
+		err = runZones(cloudFlare)
+	case "settings":
+		err = runSettings(cloudFlare, args[1:])
+	case "set":
+		err = runSet(cloudFlare, args[1:])
+	case "dns":
+		err = runDNS(cloudFlare, args[1:])
+	case "sync":
+		err = runSync(cloudFlare, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func runZones(cloudFlare *CloudFlare) error {
+	zones, err := cloudFlare.Zones()
+	if err != nil {
+		return err
+	}
+
+	for _, zone := range zones {
+		fmt.Printf("%s\t%s\n", zone.ID, zone.Name)
+	}
+	return nil
+}
+
+func runSettings(cloudFlare *CloudFlare, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: cloudflare-configure settings <zone-id>")
+	}
+
+	settings, err := cloudFlare.Settings(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, setting := range settings {
+		fmt.Printf("%s\t%v\n", setting.ID, setting.Value)
+	}
+	return nil
+}
+
+func runSet(cloudFlare *CloudFlare, args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("usage: cloudflare-configure set <zone-id> <setting-id> <value>")
+	}
+
+	return cloudFlare.Set(args[0], args[1], args[2])
+}
+
+// runDNS implements the `dns view|diff|apply` subcommand, which manages
+// the DNS records declared for a zone in a config file alongside its
+// settings.
+func runDNS(cloudFlare *CloudFlare, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: cloudflare-configure dns <view|diff|apply> ...")
+	}
+
+	switch args[0] {
+	case "view":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: cloudflare-configure dns view <zone-id>")
+		}
+		records, err := cloudFlare.DNSRecords(args[1])
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			fmt.Printf("%s\t%s\t%s\t%s\n", record.ID, record.Type, record.Name, record.Content)
+		}
+		return nil
+
+	case "diff":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: cloudflare-configure dns diff <config-file> <zone-id>")
+		}
+		diff, err := planDNSDiff(cloudFlare, args[1], args[2])
+		if err != nil {
+			return err
+		}
+		printDNSDiff(diff)
+		return nil
+
+	case "apply":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: cloudflare-configure dns apply <config-file> <zone-id>")
+		}
+		diff, err := planDNSDiff(cloudFlare, args[1], args[2])
+		if err != nil {
+			return err
+		}
+		printDNSDiff(diff)
+		return applyDNSDiff(cloudFlare, args[2], diff)
+
+	default:
+		return fmt.Errorf("unknown dns subcommand %q", args[0])
+	}
+}
+
+// planDNSDiff loads the DNS records declared for zoneID in the config
+// file at configPath and diffs them against the zone's current records.
+func planDNSDiff(cloudFlare *CloudFlare, configPath, zoneID string) (dnsRecordDiff, error) {
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		return dnsRecordDiff{}, err
+	}
+
+	zone := config.zoneByID(zoneID)
+	if zone == nil {
+		return dnsRecordDiff{}, fmt.Errorf("no zone %q declared in %s", zoneID, configPath)
+	}
+
+	current, err := cloudFlare.DNSRecords(zoneID)
+	if err != nil {
+		return dnsRecordDiff{}, err
+	}
+
+	return diffDNSRecords(current, zone.DNSRecords, zone.Name), nil
+}
+
+func printDNSDiff(diff dnsRecordDiff) {
+	for _, record := range diff.Creates {
+		fmt.Printf("+ %s %s -> %s\n", record.Type, record.Name, record.Content)
+	}
+	for _, record := range diff.Updates {
+		fmt.Printf("~ %s %s -> %s\n", record.Type, record.Name, record.Content)
+	}
+	for _, record := range diff.Deletes {
+		fmt.Printf("- %s %s -> %s\n", record.Type, record.Name, record.Content)
+	}
+}
+
+func applyDNSDiff(cloudFlare *CloudFlare, zoneID string, diff dnsRecordDiff) error {
+	for _, record := range diff.Creates {
+		if _, err := cloudFlare.CreateDNSRecord(zoneID, record); err != nil {
+			return err
+		}
+	}
+	for _, record := range diff.Updates {
+		if _, err := cloudFlare.UpdateDNSRecord(zoneID, record.ID, record); err != nil {
+			return err
+		}
+	}
+	for _, record := range diff.Deletes {
+		if err := cloudFlare.DeleteDNSRecord(zoneID, record.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runSync implements the `sync [-dry-run] <config-file>` subcommand,
+// reconciling every zone declared in the config file against its live
+// CloudFlare state.
+func runSync(cloudFlare *CloudFlare, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "print the planned changes without applying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		return fmt.Errorf("usage: cloudflare-configure sync [-dry-run] <config-file>")
+	}
+
+	config, err := LoadConfig(rest[0])
+	if err != nil {
+		return err
+	}
+
+	syncer := NewSyncer(cloudFlare)
+	plan, err := syncer.Plan(config)
+	if err != nil {
+		return err
+	}
+	printReconcilePlan(plan)
+
+	if *dryRun {
+		return nil
+	}
+
+	report := syncer.Apply(plan)
+	printSyncReport(report)
+	if report.Failures > 0 {
+		return fmt.Errorf("sync: %d change(s) failed", report.Failures)
+	}
+	return nil
+}
+
+func printReconcilePlan(plan *ReconcilePlan) {
+	for _, change := range plan.Creates {
+		fmt.Printf("+ %s\n", change.Description)
+	}
+	for _, change := range plan.Updates {
+		fmt.Printf("~ %s\n", change.Description)
+	}
+	for _, change := range plan.Deletes {
+		fmt.Printf("- %s\n", change.Description)
+	}
+}
+
+func printSyncReport(report *SyncReport) {
+	for _, result := range report.Results {
+		if result.Err != nil {
+			fmt.Printf("FAILED %s: %s\n", result.Change.Description, result.Err.Error())
+		} else {
+			fmt.Printf("OK %s\n", result.Change.Description)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Ruleset phases this tool knows how to manage. Each corresponds to a
+// single entrypoint ruleset at /zones/{id}/rulesets/phases/{phase}/entrypoint.
+const (
+	PhaseHTTPRequestCacheSettings   = "http_request_cache_settings"
+	PhaseHTTPRequestDynamicRedirect = "http_request_dynamic_redirect"
+	PhaseHTTPRequestTransform       = "http_request_transform"
+)
+
+// CloudFlareRuleset is the entrypoint ruleset for a single phase of a
+// zone, as returned by the Rulesets engine.
+type CloudFlareRuleset struct {
+	ID          string                  `json:"id,omitempty"`
+	Name        string                  `json:"name,omitempty"`
+	Description string                  `json:"description,omitempty"`
+	Kind        string                  `json:"kind,omitempty"`
+	Phase       string                  `json:"phase,omitempty"`
+	Rules       []CloudFlareRulesetRule `json:"rules"`
+}
+
+// CloudFlareRulesetRule is a single rule within a ruleset: a match
+// expression, the action it triggers, and that action's parameters.
+type CloudFlareRulesetRule struct {
+	ID               string                             `json:"id,omitempty"`
+	Description      string                             `json:"description,omitempty"`
+	Expression       string                             `json:"expression"`
+	Action           string                             `json:"action"`
+	ActionParameters *CloudFlareRulesetActionParameters `json:"action_parameters,omitempty"`
+	Enabled          bool                               `json:"enabled"`
+}
+
+// CloudFlareRulesetActionParameters covers the action parameter shapes
+// this tool supports: edge cache TTL and cache key customization (for
+// http_request_cache_settings), redirect targets (for
+// http_request_dynamic_redirect), and URI rewrites (for
+// http_request_transform).
+type CloudFlareRulesetActionParameters struct {
+	EdgeTTL   *CloudFlareEdgeTTL    `json:"edge_ttl,omitempty"`
+	CacheKey  *CloudFlareCacheKey   `json:"cache_key,omitempty"`
+	FromValue *CloudFlareRedirect   `json:"from_value,omitempty"`
+	URI       *CloudFlareURIRewrite `json:"uri,omitempty"`
+}
+
+// CloudFlareEdgeTTL configures how long a response is cached at the
+// edge.
+type CloudFlareEdgeTTL struct {
+	Mode    string `json:"mode"`
+	Default int    `json:"default,omitempty"`
+}
+
+// CloudFlareCacheKey customizes what CloudFlare hashes to form the
+// cache key for a request.
+type CloudFlareCacheKey struct {
+	CacheDeceptionArmor bool                      `json:"cache_deception_armor,omitempty"`
+	CustomKey           *CloudFlareCacheKeyFields `json:"custom_key,omitempty"`
+}
+
+// CloudFlareCacheKeyFields lists the request components folded into a
+// custom cache key.
+type CloudFlareCacheKeyFields struct {
+	QueryString *CloudFlareQueryStringKey `json:"query_string,omitempty"`
+}
+
+// CloudFlareQueryStringKey controls which query string parameters
+// participate in the cache key.
+type CloudFlareQueryStringKey struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// CloudFlareRedirect is the target of a dynamic redirect rule.
+type CloudFlareRedirect struct {
+	TargetURL           CloudFlareExpressionValue `json:"target_url"`
+	StatusCode          int                       `json:"status_code,omitempty"`
+	PreserveQueryString bool                      `json:"preserve_query_string,omitempty"`
+}
+
+// CloudFlareURIRewrite is the path/query rewrite performed by a
+// transform rule.
+type CloudFlareURIRewrite struct {
+	Path  *CloudFlareExpressionValue `json:"path,omitempty"`
+	Query *CloudFlareExpressionValue `json:"query,omitempty"`
+}
+
+// CloudFlareExpressionValue is either a literal Value or a CloudFlare
+// rules-language Expression that computes one; CloudFlare requires
+// exactly one to be set.
+type CloudFlareExpressionValue struct {
+	Value      string `json:"value,omitempty"`
+	Expression string `json:"expression,omitempty"`
+}
+
+// GetRulesetEntrypoint fetches the entrypoint ruleset for the given
+// zone and phase.
+func (c *CloudFlare) GetRulesetEntrypoint(zoneID, phase string) (CloudFlareRuleset, error) {
+	path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, phase)
+	req, err := c.Query.NewRequest("GET", path)
+	if err != nil {
+		return CloudFlareRuleset{}, err
+	}
+
+	result, err := c.makeRequest(req)
+	if err != nil {
+		return CloudFlareRuleset{}, err
+	}
+
+	var ruleset CloudFlareRuleset
+	if err := json.Unmarshal(result, &ruleset); err != nil {
+		return CloudFlareRuleset{}, err
+	}
+
+	return ruleset, nil
+}
+
+// UpdateRulesetEntrypoint replaces the rules of the entrypoint ruleset
+// for the given zone and phase.
+func (c *CloudFlare) UpdateRulesetEntrypoint(zoneID, phase string, ruleset CloudFlareRuleset) (CloudFlareRuleset, error) {
+	path := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, phase)
+	req, err := c.Query.NewRequestWithBody("PATCH", path, &ruleset)
+	if err != nil {
+		return CloudFlareRuleset{}, err
+	}
+
+	result, err := c.makeRequest(req)
+	if err != nil {
+		return CloudFlareRuleset{}, err
+	}
+
+	var updated CloudFlareRuleset
+	if err := json.Unmarshal(result, &updated); err != nil {
+		return CloudFlareRuleset{}, err
+	}
+
+	return updated, nil
+}
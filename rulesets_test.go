@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetRulesetEntrypoint(t *testing.T) {
+	testServer := testCloudFlareServer(200, `{
+		"errors": [],
+		"messages": [],
+		"result": {
+			"id": "ruleset1",
+			"phase": "http_request_cache_settings",
+			"rules": [
+				{
+					"expression": "true",
+					"action": "set_cache_settings",
+					"enabled": true,
+					"action_parameters": {"edge_ttl": {"mode": "override_origin", "default": 3600}}
+				}
+			]
+		},
+		"success": true
+	}`)
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL}
+	cloudFlare := NewCloudFlare(query)
+
+	ruleset, err := cloudFlare.GetRulesetEntrypoint("123", PhaseHTTPRequestCacheSettings)
+	if err != nil {
+		t.Fatal("Expected to get a ruleset with no errors", err)
+	}
+
+	if ruleset.ID != "ruleset1" || len(ruleset.Rules) != 1 {
+		t.Fatal("Ruleset response doesn't match", ruleset)
+	}
+	if ruleset.Rules[0].ActionParameters.EdgeTTL.Default != 3600 {
+		t.Fatal("Expected nested edge_ttl default to be parsed", ruleset.Rules[0])
+	}
+}
+
+func TestUpdateRulesetEntrypoint(t *testing.T) {
+	const zoneID = "123"
+	const phase = PhaseHTTPRequestDynamicRedirect
+	receivedRequest := false
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = true
+
+		if method := r.Method; method != "PATCH" {
+			t.Fatal("Incorrect request method", method)
+		}
+
+		expectedURL := fmt.Sprintf("/zones/%s/rulesets/phases/%s/entrypoint", zoneID, phase)
+		if r.URL.Path != expectedURL {
+			t.Fatal("Request URL was incorrect", r.URL.Path)
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal("Unable to read request body", err)
+		}
+
+		var ruleset CloudFlareRuleset
+		if err := json.Unmarshal(body, &ruleset); err != nil {
+			t.Fatal("Unable to parse request body", err)
+		}
+
+		expected := CloudFlareRuleset{
+			Rules: []CloudFlareRulesetRule{
+				{
+					Expression: `http.request.uri.path eq "/old"`,
+					Action:     "redirect",
+					Enabled:    true,
+					ActionParameters: &CloudFlareRulesetActionParameters{
+						FromValue: &CloudFlareRedirect{
+							TargetURL:           CloudFlareExpressionValue{Value: "https://example.com/new"},
+							StatusCode:          301,
+							PreserveQueryString: true,
+						},
+					},
+				},
+			},
+		}
+		if !reflect.DeepEqual(ruleset, expected) {
+			t.Fatal("Request body didn't match expected nested structure", ruleset)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{
+			"errors": [],
+			"messages": [],
+			"result": {"id": "ruleset1", "phase": "http_request_dynamic_redirect", "rules": []},
+			"success": true
+		}`)
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL}
+	cloudFlare := NewCloudFlare(query)
+
+	ruleset := CloudFlareRuleset{
+		Rules: []CloudFlareRulesetRule{
+			{
+				Expression: `http.request.uri.path eq "/old"`,
+				Action:     "redirect",
+				Enabled:    true,
+				ActionParameters: &CloudFlareRulesetActionParameters{
+					FromValue: &CloudFlareRedirect{
+						TargetURL:           CloudFlareExpressionValue{Value: "https://example.com/new"},
+						StatusCode:          301,
+						PreserveQueryString: true,
+					},
+				},
+			},
+		},
+	}
+
+	if _, err := cloudFlare.UpdateRulesetEntrypoint(zoneID, phase, ruleset); err != nil {
+		t.Fatal("Unable to update ruleset entrypoint", err)
+	}
+	if !receivedRequest {
+		t.Fatal("Expected test server to receive request")
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// PlannedChange is a single API call a Plan decided is needed to move a
+// zone from its current state to its desired state.
+type PlannedChange struct {
+	ZoneID      string
+	ZoneName    string
+	Kind        string // "setting", "dns_record" or "ruleset"
+	Method      string // HTTP method the change will issue: PATCH, POST, PUT or DELETE
+	Description string // human-readable summary, e.g. for dry-run output
+
+	SettingID    string
+	SettingValue interface{}
+
+	DNSRecord *CloudFlareDNSRecord
+
+	Phase   string
+	Ruleset *CloudFlareRuleset
+}
+
+// ReconcilePlan is the full set of changes needed to reconcile every
+// zone in a Config against its live CloudFlare state.
+type ReconcilePlan struct {
+	Creates []PlannedChange
+	Updates []PlannedChange
+	Deletes []PlannedChange
+}
+
+// SyncResult is the outcome of applying a single PlannedChange.
+type SyncResult struct {
+	Change PlannedChange
+	Err    error
+}
+
+// SyncReport is the structured outcome of an Apply: every change
+// attempted, in order, and how many failed.
+type SyncReport struct {
+	Results  []SyncResult
+	Failures int
+}
+
+// Syncer reconciles a declarative Config against live CloudFlare state.
+type Syncer struct {
+	CloudFlare *CloudFlare
+}
+
+// NewSyncer builds a Syncer that reads and writes through cloudFlare.
+func NewSyncer(cloudFlare *CloudFlare) *Syncer {
+	return &Syncer{CloudFlare: cloudFlare}
+}
+
+// Plan fetches the current state of every zone in desired and computes
+// the PlannedChanges needed to reconcile it, without making any
+// mutating calls.
+func (s *Syncer) Plan(desired *Config) (*ReconcilePlan, error) {
+	plan := &ReconcilePlan{}
+
+	for _, zone := range desired.Zones {
+		if err := s.planSettings(plan, zone); err != nil {
+			return nil, err
+		}
+		if err := s.planDNSRecords(plan, zone); err != nil {
+			return nil, err
+		}
+		if err := s.planRulesets(plan, zone); err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+func (s *Syncer) planSettings(plan *ReconcilePlan, zone ZoneConfig) error {
+	if len(zone.Settings) == 0 {
+		return nil
+	}
+
+	current, err := s.CloudFlare.Settings(zone.ID)
+	if err != nil {
+		return err
+	}
+
+	currentByID := make(map[string]CloudFlareConfigItem, len(current))
+	for _, item := range current {
+		currentByID[item.ID] = item
+	}
+
+	settingIDs := make([]string, 0, len(zone.Settings))
+	for id := range zone.Settings {
+		settingIDs = append(settingIDs, id)
+	}
+	sort.Strings(settingIDs)
+
+	for _, id := range settingIDs {
+		want := zone.Settings[id]
+		if existing, ok := currentByID[id]; ok && reflect.DeepEqual(existing.Value, want) {
+			continue
+		}
+
+		plan.Updates = append(plan.Updates, PlannedChange{
+			ZoneID:       zone.ID,
+			ZoneName:     zone.Name,
+			Kind:         "setting",
+			Method:       "PATCH",
+			SettingID:    id,
+			SettingValue: want,
+			Description:  fmt.Sprintf("PATCH /zones/%s/settings/%s -> %v", zone.ID, id, want),
+		})
+	}
+
+	return nil
+}
+
+func (s *Syncer) planDNSRecords(plan *ReconcilePlan, zone ZoneConfig) error {
+	if zone.DNSRecords == nil {
+		return nil
+	}
+
+	current, err := s.CloudFlare.DNSRecords(zone.ID)
+	if err != nil {
+		return err
+	}
+
+	diff := diffDNSRecords(current, zone.DNSRecords, zone.Name)
+
+	for _, record := range diff.Creates {
+		record := record
+		plan.Creates = append(plan.Creates, PlannedChange{
+			ZoneID:      zone.ID,
+			ZoneName:    zone.Name,
+			Kind:        "dns_record",
+			Method:      "POST",
+			DNSRecord:   &record,
+			Description: fmt.Sprintf("POST /zones/%s/dns_records -> %s %s", zone.ID, record.Type, record.Name),
+		})
+	}
+	for _, record := range diff.Updates {
+		record := record
+		plan.Updates = append(plan.Updates, PlannedChange{
+			ZoneID:      zone.ID,
+			ZoneName:    zone.Name,
+			Kind:        "dns_record",
+			Method:      "PUT",
+			DNSRecord:   &record,
+			Description: fmt.Sprintf("PUT /zones/%s/dns_records/%s -> %s %s", zone.ID, record.ID, record.Type, record.Name),
+		})
+	}
+	for _, record := range diff.Deletes {
+		record := record
+		plan.Deletes = append(plan.Deletes, PlannedChange{
+			ZoneID:      zone.ID,
+			ZoneName:    zone.Name,
+			Kind:        "dns_record",
+			Method:      "DELETE",
+			DNSRecord:   &record,
+			Description: fmt.Sprintf("DELETE /zones/%s/dns_records/%s", zone.ID, record.ID),
+		})
+	}
+
+	return nil
+}
+
+func (s *Syncer) planRulesets(plan *ReconcilePlan, zone ZoneConfig) error {
+	if len(zone.Rulesets) == 0 {
+		return nil
+	}
+
+	phases := make([]string, 0, len(zone.Rulesets))
+	for phase := range zone.Rulesets {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+
+	for _, phase := range phases {
+		want := zone.Rulesets[phase]
+
+		current, err := s.CloudFlare.GetRulesetEntrypoint(zone.ID, phase)
+		if err != nil {
+			return err
+		}
+
+		if reflect.DeepEqual(current.Rules, want.Rules) {
+			continue
+		}
+
+		plan.Updates = append(plan.Updates, PlannedChange{
+			ZoneID:      zone.ID,
+			ZoneName:    zone.Name,
+			Kind:        "ruleset",
+			Method:      "PATCH",
+			Phase:       phase,
+			Ruleset:     &want,
+			Description: fmt.Sprintf("PATCH /zones/%s/rulesets/phases/%s/entrypoint", zone.ID, phase),
+		})
+	}
+
+	return nil
+}
+
+// Apply executes plan's changes in deterministic order (creates, then
+// updates, then deletes) and reports the outcome of each.
+func (s *Syncer) Apply(plan *ReconcilePlan) *SyncReport {
+	report := &SyncReport{}
+
+	for _, change := range plan.Creates {
+		report.record(change, s.applyChange(change))
+	}
+	for _, change := range plan.Updates {
+		report.record(change, s.applyChange(change))
+	}
+	for _, change := range plan.Deletes {
+		report.record(change, s.applyChange(change))
+	}
+
+	return report
+}
+
+func (r *SyncReport) record(change PlannedChange, err error) {
+	r.Results = append(r.Results, SyncResult{Change: change, Err: err})
+	if err != nil {
+		r.Failures++
+	}
+}
+
+func (s *Syncer) applyChange(change PlannedChange) error {
+	switch change.Kind {
+	case "setting":
+		return s.CloudFlare.Set(change.ZoneID, change.SettingID, change.SettingValue)
+
+	case "dns_record":
+		switch change.Method {
+		case "POST":
+			_, err := s.CloudFlare.CreateDNSRecord(change.ZoneID, *change.DNSRecord)
+			return err
+		case "PUT":
+			_, err := s.CloudFlare.UpdateDNSRecord(change.ZoneID, change.DNSRecord.ID, *change.DNSRecord)
+			return err
+		case "DELETE":
+			return s.CloudFlare.DeleteDNSRecord(change.ZoneID, change.DNSRecord.ID)
+		}
+
+	case "ruleset":
+		_, err := s.CloudFlare.UpdateRulesetEntrypoint(change.ZoneID, change.Phase, *change.Ruleset)
+		return err
+	}
+
+	return fmt.Errorf("sync: unsupported change %s %s", change.Kind, change.Method)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSyncerPlanAndApplyMixedChanges(t *testing.T) {
+	const zoneID = "123"
+	var calls []string
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == fmt.Sprintf("/zones/%s/settings", zoneID):
+			fmt.Fprintf(w, `{
+				"errors": [], "messages": [], "success": true,
+				"result": [{"id": "always_online", "value": "off", "modified_on": "2014-07-09T11:50:56.595672Z", "editable": true}]
+			}`)
+		case r.Method == "GET" && r.URL.Path == fmt.Sprintf("/zones/%s/dns_records", zoneID):
+			fmt.Fprintf(w, `{
+				"errors": [], "messages": [], "success": true,
+				"result": [
+					{"id": "rec-stale", "type": "A", "name": "stale.example.com", "content": "9.9.9.9"},
+					{"id": "rec-www", "type": "A", "name": "www.example.com", "content": "1.1.1.1"}
+				]
+			}`)
+		default:
+			fmt.Fprintf(w, `{"errors": [], "messages": [], "success": true, "result": {}}`)
+		}
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL}
+	cloudFlare := NewCloudFlare(query)
+
+	config := &Config{
+		Zones: []ZoneConfig{
+			{
+				ID:   zoneID,
+				Name: "example.com",
+				Settings: map[string]interface{}{
+					"always_online": "on",
+				},
+				DNSRecords: []CloudFlareDNSRecord{
+					{Type: "A", Name: "www.example.com", Content: "1.1.1.1", Proxied: true},
+					{Type: "A", Name: "new.example.com", Content: "3.3.3.3"},
+				},
+			},
+		},
+	}
+
+	syncer := NewSyncer(cloudFlare)
+	plan, err := syncer.Plan(config)
+	if err != nil {
+		t.Fatal("Unable to build plan", err)
+	}
+
+	if len(plan.Creates) != 1 || plan.Creates[0].DNSRecord.Name != "new.example.com" {
+		t.Fatal("Expected one DNS record create", plan.Creates)
+	}
+	if len(plan.Updates) != 2 {
+		t.Fatal("Expected one setting update and one DNS record update", plan.Updates)
+	}
+	if len(plan.Deletes) != 1 || plan.Deletes[0].DNSRecord.ID != "rec-stale" {
+		t.Fatal("Expected one DNS record delete", plan.Deletes)
+	}
+
+	calls = nil // reset the calls recorded while planning
+	report := syncer.Apply(plan)
+	if report.Failures != 0 {
+		t.Fatal("Expected every change to apply cleanly", report.Results)
+	}
+
+	expectedCalls := []string{
+		"POST /zones/123/dns_records",
+		"PATCH /zones/123/settings/always_online",
+		"PUT /zones/123/dns_records/rec-www",
+		"DELETE /zones/123/dns_records/rec-stale",
+	}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Fatal("Apply didn't issue calls in the expected deterministic order", calls)
+	}
+}
+
+func TestSyncerPlanDryRunMakesNoMutatingCalls(t *testing.T) {
+	const zoneID = "123"
+	var sawMutation bool
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			sawMutation = true
+		}
+		fmt.Fprintf(w, `{"errors": [], "messages": [], "success": true, "result": []}`)
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL}
+	cloudFlare := NewCloudFlare(query)
+
+	config := &Config{
+		Zones: []ZoneConfig{
+			{
+				ID:   zoneID,
+				Name: "example.com",
+				DNSRecords: []CloudFlareDNSRecord{
+					{Type: "A", Name: "www.example.com", Content: "1.1.1.1"},
+				},
+			},
+		},
+	}
+
+	syncer := NewSyncer(cloudFlare)
+	if _, err := syncer.Plan(config); err != nil {
+		t.Fatal("Unable to build plan", err)
+	}
+
+	if sawMutation {
+		t.Fatal("Plan should never issue a mutating call")
+	}
+}
+
+func TestSyncerPlansAndAppliesRulesetChanges(t *testing.T) {
+	const zoneID = "123"
+	const phase = PhaseHTTPRequestCacheSettings
+	var calls []string
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		fmt.Fprintf(w, `{
+			"errors": [], "messages": [], "success": true,
+			"result": {"id": "ruleset1", "phase": "http_request_cache_settings", "rules": []}
+		}`)
+	}))
+	defer testServer.Close()
+
+	query := &CloudFlareQuery{RootURL: testServer.URL}
+	cloudFlare := NewCloudFlare(query)
+
+	config := &Config{
+		Zones: []ZoneConfig{
+			{
+				ID:   zoneID,
+				Name: "example.com",
+				Rulesets: map[string]CloudFlareRuleset{
+					phase: {
+						Rules: []CloudFlareRulesetRule{
+							{
+								Expression: "true",
+								Action:     "set_cache_settings",
+								Enabled:    true,
+								ActionParameters: &CloudFlareRulesetActionParameters{
+									EdgeTTL: &CloudFlareEdgeTTL{Mode: "override_origin", Default: 3600},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	syncer := NewSyncer(cloudFlare)
+	plan, err := syncer.Plan(config)
+	if err != nil {
+		t.Fatal("Unable to build plan", err)
+	}
+
+	if len(plan.Updates) != 1 || plan.Updates[0].Kind != "ruleset" || plan.Updates[0].Phase != phase {
+		t.Fatal("Expected one ruleset update", plan.Updates)
+	}
+
+	calls = nil
+	report := syncer.Apply(plan)
+	if report.Failures != 0 {
+		t.Fatal("Expected the ruleset update to apply cleanly", report.Results)
+	}
+
+	expectedCalls := []string{
+		fmt.Sprintf("PATCH /zones/%s/rulesets/phases/%s/entrypoint", zoneID, phase),
+	}
+	if !reflect.DeepEqual(calls, expectedCalls) {
+		t.Fatal("Apply didn't issue the expected ruleset PATCH", calls)
+	}
+}